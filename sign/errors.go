@@ -0,0 +1,37 @@
+package sign
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+)
+
+var (
+	// ErrSignReqNotFound is returned when a request with a given ID isn't
+	// found among pending requests.
+	ErrSignReqNotFound = errors.New("sign request with given ID doesn't exist")
+	// ErrSignReqInProgress is returned when a request with a given ID is
+	// already being processed.
+	ErrSignReqInProgress = errors.New("sign request with given ID is in progress")
+	// ErrSignReqDiscarded is returned (and stored as a request's result)
+	// when a pending request is discarded by the user.
+	ErrSignReqDiscarded = errors.New("sign request is discarded")
+	// ErrSignReqCancelled is returned (and stored as a request's result)
+	// when a pending request is cancelled programmatically, e.g. because
+	// the context.Context it was added with is done, rather than being
+	// discarded by the user. See PendingRequests.Cancel.
+	ErrSignReqCancelled = errors.New("sign request is cancelled")
+	// ErrSignReqTimedOut is returned (and stored as a request's result)
+	// when a pending request expires before it's approved or discarded.
+	ErrSignReqTimedOut = errors.New("sign request timed out")
+	// ErrSignReqQueueFull is returned by Add when the global or per-account
+	// cap on pending requests has been reached and the caller opted not to
+	// wait for space to free up.
+	ErrSignReqQueueFull = errors.New("sign request queue is full")
+)
+
+// isTransient reports whether err should leave a request pending (so that it
+// can be retried by the caller) rather than removing it from the queue.
+func isTransient(err error) bool {
+	return err == keystore.ErrDecrypt
+}
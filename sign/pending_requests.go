@@ -3,6 +3,7 @@ package sign
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	gethcommon "github.com/ethereum/go-ethereum/common"
@@ -12,38 +13,288 @@ import (
 
 type verifyFunc func(string) (*account.SelectedExtKey, error)
 
+const (
+	// DefaultMaxInflightRequests is the default global cap on the number of
+	// requests a PendingRequests container will hold at once.
+	DefaultMaxInflightRequests = 100
+	// DefaultMaxPerAccountRequests is the default cap on the number of
+	// pending requests a single account may have queued at once.
+	DefaultMaxPerAccountRequests = 10
+	// defaultWaitPollInterval is how often a blocked Add re-checks capacity.
+	defaultWaitPollInterval = 50 * time.Millisecond
+	// DefaultRequestTTL is the default time a request may stay pending
+	// before the background sweeper expires it with ErrSignReqTimedOut.
+	DefaultRequestTTL = 10 * time.Minute
+	// defaultSweepInterval is how often the background sweeper scans for
+	// expired requests.
+	defaultSweepInterval = 30 * time.Second
+	// DefaultSignTimeout bounds how long a single Signer.Sign dispatch (a
+	// hardware-wallet confirmation, a remote KMS round trip) may run before
+	// it's cancelled, so an unreachable KMS endpoint or a never-confirmed
+	// device prompt can't hang Approve (and keep the request locked)
+	// indefinitely.
+	DefaultSignTimeout = 2 * time.Minute
+)
+
+// Config controls the capacity limits and backpressure policy of a
+// PendingRequests container.
+type Config struct {
+	// MaxInflight is the maximum number of requests that may be pending at
+	// once, across all accounts. Zero means unlimited.
+	MaxInflight int
+	// MaxPerAccount is the maximum number of pending requests a single
+	// account (as found on a Request's Meta) may hold at once. Zero means
+	// unlimited. Requests with no account (empty Meta.Account) are only
+	// subject to MaxInflight.
+	MaxPerAccount int
+	// Wait selects the backpressure policy once a cap is reached: if true,
+	// Add blocks until space frees up or ctx is done; if false, Add returns
+	// ErrSignReqQueueFull immediately.
+	Wait bool
+	// DefaultTTL is the TTL a request added via Add gets if it doesn't pass
+	// a per-call override to AddWithTTL. Zero means requests never expire
+	// on their own.
+	DefaultTTL time.Duration
+	// SweepInterval is how often the background sweeper scans for expired
+	// requests. Zero uses defaultSweepInterval.
+	SweepInterval time.Duration
+	// SignerSelector resolves which Signer should handle a request, based
+	// on its account (e.g. a hardware wallet address vs. a local keystore
+	// one). Nil, or a selector returning ErrNoSignerForAccount, falls back
+	// to the default keystore signer built from Approve's password/verify
+	// arguments.
+	SignerSelector SignerSelector
+	// SignTimeout bounds how long Approve's dispatch to a Signer may run.
+	// Zero uses DefaultSignTimeout. It reaches the Signer as the deadline of
+	// Sign's ctx; KeystoreSigner, which signs locally, doesn't check ctx.
+	SignTimeout time.Duration
+}
+
+// DefaultConfig returns the Config used by NewPendingRequests.
+func DefaultConfig() Config {
+	return Config{
+		MaxInflight:   DefaultMaxInflightRequests,
+		MaxPerAccount: DefaultMaxPerAccountRequests,
+		Wait:          true,
+		DefaultTTL:    DefaultRequestTTL,
+		SweepInterval: defaultSweepInterval,
+		SignTimeout:   DefaultSignTimeout,
+	}
+}
+
+// Metrics is a point-in-time snapshot of a PendingRequests container's
+// request counters.
+type Metrics struct {
+	Accepted uint64
+	Rejected uint64
+	Evicted  uint64
+}
+
+// counters holds the atomically-updated fields backing Metrics.
+type counters struct {
+	accepted uint64
+	rejected uint64
+	evicted  uint64
+}
+
 // PendingRequests is a capped container that holds pending signing requests.
 type PendingRequests struct {
-	mu       sync.RWMutex // to guard transactions map
-	requests map[string]*Request
+	mu         sync.RWMutex // to guard transactions map
+	requests   map[string]*Request
+	perAccount map[string]int // count of pending requests per Meta.Account
+
+	cfg      Config
+	counters counters
+
+	stop     chan struct{}
+	stopOnce sync.Once
 
 	log log.Logger
 }
 
-// NewPendingRequests creates a new requests list
+// NewPendingRequests creates a new requests list, capped and throttled
+// according to DefaultConfig.
 func NewPendingRequests() *PendingRequests {
+	return NewPendingRequestsWithConfig(DefaultConfig())
+}
+
+// NewPendingRequestsWithConfig creates a new requests list governed by cfg,
+// and starts the background goroutine that expires overdue requests.
+func NewPendingRequestsWithConfig(cfg Config) *PendingRequests {
 	logger := log.New("package", "status-go/sign.PendingRequests")
 
-	return &PendingRequests{
-		requests: make(map[string]*Request),
-		log:      logger,
+	rs := &PendingRequests{
+		requests:   make(map[string]*Request),
+		perAccount: make(map[string]int),
+		cfg:        cfg,
+		stop:       make(chan struct{}),
+		log:        logger,
+	}
+	go rs.sweepExpired()
+
+	return rs
+}
+
+// Stop terminates the background expiry sweeper. It is safe to call more
+// than once.
+func (rs *PendingRequests) Stop() {
+	rs.stopOnce.Do(func() { close(rs.stop) })
+}
+
+// sweepExpired periodically expires requests whose TTL has elapsed.
+func (rs *PendingRequests) sweepExpired() {
+	interval := rs.cfg.SweepInterval
+	if interval <= 0 {
+		interval = defaultSweepInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rs.expireOverdue()
+		case <-rs.stop:
+			return
+		}
+	}
+}
+
+// expireOverdue completes, with ErrSignReqTimedOut, every pending request
+// whose TTL has elapsed.
+func (rs *PendingRequests) expireOverdue() {
+	now := time.Now()
+
+	rs.mu.RLock()
+	var overdue []*Request
+	for _, request := range rs.requests {
+		if !request.expiresAt.IsZero() && now.After(request.expiresAt) {
+			overdue = append(overdue, request)
+		}
+	}
+	rs.mu.RUnlock()
+
+	for _, request := range overdue {
+		if _, err := rs.tryLock(request.ID); err != nil {
+			continue
+		}
+		atomic.AddUint64(&rs.counters.evicted, 1)
+		rs.complete(request, nil, ErrSignReqTimedOut)
 	}
 }
 
-// Add a new signing request.
+// watchCtx auto-cancels request once the context.Context it was added with
+// is done, unless the request completes first.
+func (rs *PendingRequests) watchCtx(request *Request) {
+	if request.ctx == nil {
+		return
+	}
+	select {
+	case <-request.ctx.Done():
+		if _, err := rs.tryLock(request.ID); err == nil {
+			rs.complete(request, nil, ErrSignReqCancelled)
+		}
+	case <-request.done:
+	}
+}
+
+// Add a new signing request with the container's default TTL (Config.DefaultTTL).
+// If the container is at capacity (globally via Config.MaxInflight, or for
+// meta.Account via Config.MaxPerAccount), Add either blocks until space is
+// available or ctx is done (Config.Wait), or returns ErrSignReqQueueFull
+// immediately (!Config.Wait). Once added, ctx.Done() auto-cancels the
+// request with ErrSignReqCancelled.
 func (rs *PendingRequests) Add(ctx context.Context, meta Meta, completeFunc completeFunc) (*Request, error) {
+	return rs.AddWithTTL(ctx, meta, completeFunc, rs.cfg.DefaultTTL)
+}
+
+// AddWithTTL behaves like Add, but expires the request after ttl (zero
+// meaning no expiry) instead of the container's default TTL.
+func (rs *PendingRequests) AddWithTTL(ctx context.Context, meta Meta, completeFunc completeFunc, ttl time.Duration) (*Request, error) {
+	for {
+		request, err := rs.tryAdd(ctx, meta, completeFunc, ttl)
+		if err == nil {
+			rs.log.Info("signing request is created", "ID", request.ID)
+			go rs.watchCtx(request)
+			go NotifyOnEnqueue(request)
+			return request, nil
+		}
+		if err != ErrSignReqQueueFull || !rs.cfg.Wait {
+			atomic.AddUint64(&rs.counters.rejected, 1)
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			atomic.AddUint64(&rs.counters.rejected, 1)
+			return nil, ctx.Err()
+		case <-time.After(defaultWaitPollInterval):
+		}
+	}
+}
+
+// tryAdd inserts a new request if the queue has capacity for it, returning
+// ErrSignReqQueueFull otherwise.
+func (rs *PendingRequests) tryAdd(ctx context.Context, meta Meta, completeFunc completeFunc, ttl time.Duration) (*Request, error) {
+	rs.mu.Lock()
+	if !rs.hasCapacityLocked(meta) {
+		rs.mu.Unlock()
+		return nil, ErrSignReqQueueFull
+	}
+	rs.mu.Unlock()
+
+	// selectSigner may run a caller-supplied SignerSelector that does real
+	// work (a USB-wallet lookup, a KMS config check), so it must not run
+	// while rs.mu is held: every other method on this type contends on the
+	// same mutex, and a selector calling back into this instance would
+	// deadlock outright since sync.RWMutex isn't reentrant.
+	request := newRequest(ctx, meta, completeFunc, ttl)
+	if signer, err := rs.selectSigner(request); err == nil {
+		request.SignerKind = signer.Kind()
+	}
+
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
 
-	request := newRequest(ctx, meta, completeFunc)
-	rs.requests[request.ID] = request
-	rs.log.Info("signing request is created", "ID", request.ID)
+	// Capacity may have been taken up by another Add while selectSigner ran
+	// unlocked above, so it's re-checked here before committing request.
+	if !rs.hasCapacityLocked(meta) {
+		return nil, ErrSignReqQueueFull
+	}
 
-	go NotifyOnEnqueue(request)
+	rs.requests[request.ID] = request
+	if meta.Account != "" {
+		rs.perAccount[meta.Account]++
+	}
+	atomic.AddUint64(&rs.counters.accepted, 1)
 
 	return request, nil
 }
 
+// hasCapacityLocked reports whether the container has room for another
+// request with the given meta under Config.MaxInflight/MaxPerAccount. The
+// caller must hold rs.mu.
+func (rs *PendingRequests) hasCapacityLocked(meta Meta) bool {
+	if rs.cfg.MaxInflight > 0 && len(rs.requests) >= rs.cfg.MaxInflight {
+		return false
+	}
+	if rs.cfg.MaxPerAccount > 0 && meta.Account != "" && rs.perAccount[meta.Account] >= rs.cfg.MaxPerAccount {
+		return false
+	}
+	return true
+}
+
+// selectSigner resolves the Signer that should handle request via
+// Config.SignerSelector. It returns ErrNoSignerForAccount if no selector is
+// configured or the configured one doesn't recognize the account; callers
+// should fall back to the default keystore signer in that case.
+func (rs *PendingRequests) selectSigner(request *Request) (Signer, error) {
+	if rs.cfg.SignerSelector == nil {
+		return nil, ErrNoSignerForAccount
+	}
+	return rs.cfg.SignerSelector(request)
+}
+
 // Get returns a signing request by it's ID.
 func (rs *PendingRequests) Get(id string) (*Request, error) {
 	rs.mu.RLock()
@@ -67,37 +318,75 @@ func (rs *PendingRequests) First() *Request {
 	return nil
 }
 
-// Approve a signing request by it's ID. Requires a valid password and a verification function.
-func (rs *PendingRequests) Approve(id string, password string, verify verifyFunc) (hash gethcommon.Hash, err error) {
+// Approve a signing request by it's ID. password and verify are used only
+// if the request falls back to the default keystore signer; requests
+// dispatched to a hardware wallet or remote KMS signer (see
+// Config.SignerSelector) ignore them.
+func (rs *PendingRequests) Approve(id string, password string, verify verifyFunc) (response []byte, err error) {
 	rs.log.Info("complete transaction", "id", id)
 	request, err := rs.tryLock(id)
 	if err != nil {
 		rs.log.Warn("can't process transaction", "err", err)
-		return hash, err
+		return response, err
 	}
 
-	selectedAccount, err := verify(password)
+	signer, err := rs.selectSigner(request)
 	if err != nil {
-		rs.complete(request, hash, err)
-		return hash, err
+		signer = NewKeystoreSigner(password, verify)
+	}
+
+	// Bound the dispatch instead of using context.Background(): an
+	// unreachable RemoteKMSSigner endpoint, or a never-confirmed
+	// HardwareWalletSigner prompt, would otherwise hang Approve indefinitely
+	// and leave the request locked, so Discard, Cancel and the expiry sweeper
+	// could never complete it. Deriving from the request's own ctx also
+	// means Sign is cancelled if the original caller already went away.
+	signCtx := request.ctx
+	if signCtx == nil {
+		signCtx = context.Background()
 	}
+	timeout := rs.cfg.SignTimeout
+	if timeout <= 0 {
+		timeout = DefaultSignTimeout
+	}
+	signCtx, cancel := context.WithTimeout(signCtx, timeout)
+	defer cancel()
 
-	hash, err = request.completeFunc(selectedAccount)
-	rs.log.Info("finally completed transaction", "id", request.ID, "hash", hash, "err", err)
+	result := signer.Sign(signCtx, request)
+	rs.log.Info("finally completed transaction", "id", request.ID, "signer", signer.Kind(), "response", gethcommon.Bytes2Hex(result.Response), "err", result.Error)
 
-	rs.complete(request, hash, err)
+	rs.complete(request, result.Response, result.Error)
 
-	return hash, err
+	return result.Response, result.Error
 }
 
-// Discard remove a signing request from the list of pending requests.
+// Discard removes a signing request from the list of pending requests,
+// representing the user explicitly rejecting it. Like expireOverdue and
+// watchCtx, it tryLocks the request before completing it, so it can't race
+// an in-flight Approve: if the request is already being signed, Discard
+// returns ErrSignReqInProgress instead of completing it out from under
+// Approve.
 func (rs *PendingRequests) Discard(id string) error {
-	request, err := rs.Get(id)
+	request, err := rs.tryLock(id)
+	if err != nil {
+		return err
+	}
+
+	rs.complete(request, nil, ErrSignReqDiscarded)
+	return nil
+}
+
+// Cancel removes a signing request from the list of pending requests,
+// representing a programmatic cancellation (e.g. the caller that created it
+// went away) rather than the user rejecting it. See Discard for the latter
+// and for why it tryLocks the request before completing it.
+func (rs *PendingRequests) Cancel(id string) error {
+	request, err := rs.tryLock(id)
 	if err != nil {
 		return err
 	}
 
-	rs.complete(request, gethcommon.Hash{}, ErrSignReqDiscarded)
+	rs.complete(request, nil, ErrSignReqCancelled)
 	return nil
 }
 
@@ -112,7 +401,12 @@ func (rs *PendingRequests) Wait(id string, timeout time.Duration) Result {
 		case rst := <-request.result:
 			return rst
 		case <-time.After(timeout):
-			rs.complete(request, gethcommon.Hash{}, ErrSignReqTimedOut)
+			// Like expireOverdue, tryLock before completing: if Approve is
+			// already in flight, leave the request alone and loop back to
+			// wait on request.result instead of racing it.
+			if _, err := rs.tryLock(id); err == nil {
+				rs.complete(request, nil, ErrSignReqTimedOut)
+			}
 		}
 	}
 }
@@ -132,6 +426,16 @@ func (rs *PendingRequests) Has(id string) bool {
 	return ok
 }
 
+// Metrics returns a snapshot of the accepted/rejected/evicted request
+// counters, suitable for exposing through status-go's metrics endpoint.
+func (rs *PendingRequests) Metrics() Metrics {
+	return Metrics{
+		Accepted: atomic.LoadUint64(&rs.counters.accepted),
+		Rejected: atomic.LoadUint64(&rs.counters.rejected),
+		Evicted:  atomic.LoadUint64(&rs.counters.evicted),
+	}
+}
+
 // tryLock is used to avoid double-completion of the same request.
 // it returns a request instance if it isn't processing yet, returns an error otherwise.
 func (rs *PendingRequests) tryLock(id string) (*Request, error) {
@@ -148,7 +452,7 @@ func (rs *PendingRequests) tryLock(id string) (*Request, error) {
 }
 
 // complete removes the request from the list if there is no error or an error is non-transient
-func (rs *PendingRequests) complete(request *Request, hash gethcommon.Hash, err error) {
+func (rs *PendingRequests) complete(request *Request, response []byte, err error) {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
 
@@ -160,13 +464,35 @@ func (rs *PendingRequests) complete(request *Request, hash gethcommon.Hash, err
 		return
 	}
 
-	delete(rs.requests, request.ID)
+	// done must only close once the request is actually gone: watchCtx
+	// selects on it alongside request.ctx.Done() and exits for good the
+	// moment it fires, so closing it on a transient completion (e.g. a
+	// wrong-password Approve, which leaves the request pending for retry)
+	// would stop ctx cancellation from ever auto-discarding it again.
+	request.closeDone.Do(func() { close(request.done) })
 
-	// hash is updated only if err is nil, but transaction is not removed from a queue
+	rs.removeLocked(request)
+
+	// response is set only if err is nil, but the request is still removed from the queue
 	result := Result{Error: err}
 	if err == nil {
-		result.Hash = hash
+		result.Response = response
 	}
 
 	request.result <- result
 }
+
+// removeLocked deletes request from the requests map and decrements its
+// account's quota usage. The caller must hold rs.mu.
+func (rs *PendingRequests) removeLocked(request *Request) {
+	if _, ok := rs.requests[request.ID]; !ok {
+		return
+	}
+	delete(rs.requests, request.ID)
+	if account := request.Meta.Account; account != "" && rs.perAccount[account] > 0 {
+		rs.perAccount[account]--
+		if rs.perAccount[account] == 0 {
+			delete(rs.perAccount, account)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package sign
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/stretchr/testify/require"
+)
+
+// noopCompleteFunc is a completeFunc that succeeds without touching the
+// selected account, for tests that only care about queue bookkeeping.
+func noopCompleteFunc(*account.SelectedExtKey) ([]byte, error) {
+	return []byte{0x01}, nil
+}
+
+func TestAdd_MaxInflightRejectsWithoutWait(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxInflight = 1
+	cfg.Wait = false
+	rs := NewPendingRequestsWithConfig(cfg)
+	defer rs.Stop()
+
+	_, err := rs.Add(context.Background(), Meta{Account: "0x1"}, noopCompleteFunc)
+	require.NoError(t, err)
+
+	_, err = rs.Add(context.Background(), Meta{Account: "0x2"}, noopCompleteFunc)
+	require.Equal(t, ErrSignReqQueueFull, err)
+	require.Equal(t, uint64(1), rs.Metrics().Rejected)
+}
+
+func TestAdd_MaxInflightBlocksUntilSpaceFrees(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxInflight = 1
+	cfg.Wait = true
+	rs := NewPendingRequestsWithConfig(cfg)
+	defer rs.Stop()
+
+	first, err := rs.Add(context.Background(), Meta{Account: "0x1"}, noopCompleteFunc)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := rs.Add(context.Background(), Meta{Account: "0x2"}, noopCompleteFunc)
+		require.NoError(t, err)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Add returned before capacity freed up")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, rs.Discard(first.ID))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Add never unblocked after capacity freed up")
+	}
+}
+
+func TestAdd_MaxInflightBlockedAddIsCancelledByCtx(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxInflight = 1
+	cfg.Wait = true
+	rs := NewPendingRequestsWithConfig(cfg)
+	defer rs.Stop()
+
+	_, err := rs.Add(context.Background(), Meta{Account: "0x1"}, noopCompleteFunc)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = rs.Add(ctx, Meta{Account: "0x2"}, noopCompleteFunc)
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Equal(t, uint64(1), rs.Metrics().Rejected)
+}
+
+func TestAdd_MaxPerAccountQuota(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxInflight = 0
+	cfg.MaxPerAccount = 1
+	cfg.Wait = false
+	rs := NewPendingRequestsWithConfig(cfg)
+	defer rs.Stop()
+
+	_, err := rs.Add(context.Background(), Meta{Account: "0x1"}, noopCompleteFunc)
+	require.NoError(t, err)
+
+	_, err = rs.Add(context.Background(), Meta{Account: "0x1"}, noopCompleteFunc)
+	require.Equal(t, ErrSignReqQueueFull, err)
+
+	// A different account is unaffected by 0x1's quota.
+	_, err = rs.Add(context.Background(), Meta{Account: "0x2"}, noopCompleteFunc)
+	require.NoError(t, err)
+}
+
+func TestExpireOverdue_SweepsTimedOutRequests(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DefaultTTL = 20 * time.Millisecond
+	cfg.SweepInterval = 10 * time.Millisecond
+	rs := NewPendingRequestsWithConfig(cfg)
+	defer rs.Stop()
+
+	request, err := rs.Add(context.Background(), Meta{Account: "0x1"}, noopCompleteFunc)
+	require.NoError(t, err)
+
+	result := rs.Wait(request.ID, time.Second)
+	require.Equal(t, ErrSignReqTimedOut, result.Error)
+	require.Equal(t, uint64(1), rs.Metrics().Evicted)
+}
+
+func TestCancelVsDiscard_DistinctReasons(t *testing.T) {
+	rs := NewPendingRequests()
+	defer rs.Stop()
+
+	// Discard/Cancel complete and remove the request in one step, so the
+	// result is read directly off request.result here rather than through
+	// Wait(id, ...): once removed, Wait's own Get(id) would see it as gone
+	// rather than returning the reason it was removed for.
+	discarded, err := rs.Add(context.Background(), Meta{Account: "0x1"}, noopCompleteFunc)
+	require.NoError(t, err)
+	require.NoError(t, rs.Discard(discarded.ID))
+	result := <-discarded.result
+	require.Equal(t, ErrSignReqDiscarded, result.Error)
+	require.Equal(t, ReasonDiscarded, reasonFor(result.Error))
+
+	cancelled, err := rs.Add(context.Background(), Meta{Account: "0x1"}, noopCompleteFunc)
+	require.NoError(t, err)
+	require.NoError(t, rs.Cancel(cancelled.ID))
+	result = <-cancelled.result
+	require.Equal(t, ErrSignReqCancelled, result.Error)
+	require.Equal(t, ReasonCancelled, reasonFor(result.Error))
+}
+
+// TestCancel_DuringApproveDoesNotRace is a regression test for a deadlock:
+// Approve tryLocks a request, then calls a Signer that can take a while (a
+// hardware wallet confirmation, a remote KMS round trip). If Cancel/Discard
+// completed the request unconditionally instead of tryLocking it first, it
+// would race Approve, complete the request first and fill request.result's
+// buffer; when Approve's own Signer.Sign eventually returned and tried to
+// complete the same request, the buffered channel send in complete() would
+// block forever while still holding rs.mu, wedging the whole instance.
+func TestCancel_DuringApproveDoesNotRace(t *testing.T) {
+	rs := NewPendingRequests()
+	defer rs.Stop()
+
+	approveStarted := make(chan struct{})
+	unblockApprove := make(chan struct{})
+	slowComplete := func(*account.SelectedExtKey) ([]byte, error) {
+		close(approveStarted)
+		<-unblockApprove
+		return []byte{0x01}, nil
+	}
+
+	request, err := rs.Add(context.Background(), Meta{Account: "0x1"}, slowComplete)
+	require.NoError(t, err)
+
+	approveDone := make(chan struct{})
+	go func() {
+		defer close(approveDone)
+		_, _ = rs.Approve(request.ID, "password", func(string) (*account.SelectedExtKey, error) {
+			return &account.SelectedExtKey{}, nil
+		})
+	}()
+
+	<-approveStarted
+
+	// Cancel races the in-flight Approve; it must not complete the request
+	// out from under it.
+	require.Equal(t, ErrSignReqInProgress, rs.Cancel(request.ID))
+
+	close(unblockApprove)
+
+	select {
+	case <-approveDone:
+	case <-time.After(time.Second):
+		t.Fatal("Approve never completed: complete() likely deadlocked on rs.mu")
+	}
+
+	// Approve has already completed (and removed) the request by this point,
+	// so read the result directly off request.result rather than through
+	// Wait(id, ...), whose Get(id) would otherwise see it as gone.
+	result := <-request.result
+	require.NoError(t, result.Error)
+}
+
+// TestWatchCtx_SurvivesTransientApproveFailure is a regression test: complete
+// used to close request.done unconditionally, even on a transient failure
+// (e.g. a wrong password) that leaves the request pending for retry.
+// watchCtx's select on request.ctx.Done()/request.done would then exit for
+// good on that first transient failure, so a later cancellation of the
+// original ctx no longer auto-discarded the request.
+func TestWatchCtx_SurvivesTransientApproveFailure(t *testing.T) {
+	rs := NewPendingRequests()
+	defer rs.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	request, err := rs.Add(ctx, Meta{Account: "0x1"}, noopCompleteFunc)
+	require.NoError(t, err)
+
+	_, err = rs.Approve(request.ID, "wrong-password", func(string) (*account.SelectedExtKey, error) {
+		return nil, keystore.ErrDecrypt
+	})
+	require.Equal(t, keystore.ErrDecrypt, err)
+
+	// The request is still pending after a transient failure.
+	_, err = rs.Get(request.ID)
+	require.NoError(t, err)
+
+	cancel()
+
+	result := <-request.result
+	require.Equal(t, ErrSignReqCancelled, result.Error)
+}
@@ -0,0 +1,150 @@
+package sign
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/geth/account"
+)
+
+// completeFunc is invoked with the unlocked account key once a request has
+// been approved, and performs the actual signing/transaction operation. It
+// returns the raw result bytes: a 32-byte transaction hash for requests
+// that produce one (e.g. eth_sendTransaction), or a 65-byte r||s||v
+// signature for personal_sign and eth_signTypedData_v4 (see
+// PersonalSignCompleteFunc and TypedDataCompleteFunc).
+type completeFunc func(acc *account.SelectedExtKey) ([]byte, error)
+
+// Meta carries the data needed to identify a pending request and to render
+// an approval prompt for it (method name, originating account and any
+// method-specific payload).
+type Meta struct {
+	Method  string `json:"method"`
+	Account string `json:"account"`
+	Data    string `json:"data,omitempty"`
+
+	// TypedData is set for eth_signTypedData_v4 requests and carries the
+	// parsed EIP-712 payload being signed.
+	TypedData *TypedData `json:"typedData,omitempty"`
+}
+
+// Result is returned to Approve/Wait callers once a request has been
+// completed, either with a response or with an error.
+type Result struct {
+	Response []byte
+	Error    error
+}
+
+// Hash returns Response as a 32-byte transaction hash. It is meaningful
+// only for requests that produce one; for a 65-byte signature (e.g.
+// eth_signTypedData_v4) use Response directly.
+func (r Result) Hash() gethcommon.Hash {
+	return gethcommon.BytesToHash(r.Response)
+}
+
+// Request is a single signing request, pending approval or discard.
+type Request struct {
+	ID     string
+	Method string
+	Meta   Meta
+
+	// SignerKind identifies which Signer will handle this request if
+	// approved (resolved at Add time via Config.SignerSelector, defaulting
+	// to SignerKeystore), so the notification sent on enqueue can tell the
+	// UI which approval flow to render.
+	SignerKind SignerKind
+
+	// ctx is the context.Context the request was added with. Its
+	// cancellation auto-cancels the request (see PendingRequests.watchCtx).
+	ctx context.Context
+	// expiresAt is when the request should be auto-completed with
+	// ErrSignReqTimedOut by the background sweeper. Zero means no TTL.
+	expiresAt time.Time
+
+	locked bool
+	result chan Result
+	// done is closed once the request completes, so that goroutines
+	// watching it (e.g. watchCtx) can stop waiting on it.
+	done      chan struct{}
+	closeDone sync.Once
+
+	completeFunc completeFunc
+}
+
+// newRequest creates a new pending Request for the given Meta and
+// completeFunc, expiring after ttl (zero meaning no expiry) unless
+// completed sooner.
+func newRequest(ctx context.Context, meta Meta, completeFunc completeFunc, ttl time.Duration) *Request {
+	request := &Request{
+		ID:           newRequestID(),
+		Method:       meta.Method,
+		Meta:         meta,
+		SignerKind:   SignerKeystore,
+		ctx:          ctx,
+		result:       make(chan Result, 1),
+		done:         make(chan struct{}),
+		completeFunc: completeFunc,
+	}
+	if ttl > 0 {
+		request.expiresAt = time.Now().Add(ttl)
+	}
+	return request
+}
+
+// newRequestID generates a random hex-encoded identifier for a new request.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// PersonalSignCompleteFunc returns a completeFunc that signs the
+// personal_sign digest of data (the EIP-191 "\x19Ethereum Signed Message:\n"
+// prefixed hash of the hex-decoded payload) with the selected account's key,
+// producing a 65-byte r||s||v signature. It is the canonical completeFunc
+// for every request whose Meta.TypedData is unset: signingDigest, used by
+// every Signer in signer.go, derives the exact same bytes, so a request
+// signs identically regardless of which Signer handles it.
+func PersonalSignCompleteFunc(data string) completeFunc {
+	return func(selectedAccount *account.SelectedExtKey) ([]byte, error) {
+		hash, err := signingDigest(Meta{Data: data})
+		if err != nil {
+			return nil, err
+		}
+
+		sig, err := crypto.Sign(hash.Bytes(), selectedAccount.AccountKey.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		// crypto.Sign returns v in the {0,1} range; personal_sign
+		// signatures, like EIP-712 ones, use the {27,28} range.
+		sig[64] += 27
+
+		return sig, nil
+	}
+}
+
+// signingDigest returns the 32-byte digest that must be signed for a
+// request identified by meta: the EIP-712 domain/struct hash if
+// meta.TypedData is set, or the EIP-191 personal_sign digest of the
+// hex-decoded meta.Data otherwise. completeFunc implementations built by
+// this package, and every Signer in signer.go, derive the bytes they sign
+// from this single function, so the same Request always produces the same
+// signature regardless of which Signer handles it.
+func signingDigest(meta Meta) (gethcommon.Hash, error) {
+	if meta.TypedData != nil {
+		return hashTypedData(*meta.TypedData)
+	}
+	data, err := hexutil.Decode(meta.Data)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+	return gethcommon.BytesToHash(accounts.TextHash(data)), nil
+}
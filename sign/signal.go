@@ -0,0 +1,126 @@
+package sign
+
+import (
+	statussignal "github.com/status-im/status-go/geth/signal"
+)
+
+// Signal events emitted over the course of a sign request's lifetime.
+const (
+	// EventSignRequestAdded is triggered when a new sign request is added
+	// to the pending requests queue.
+	EventSignRequestAdded = "sign-request-added"
+	// EventSignRequestFailed is triggered when a pending sign request
+	// completes with an error (discarded, cancelled, timed out or failed to
+	// sign).
+	EventSignRequestFailed = "sign-request-failed"
+	// EventSignRequestDeviceConfirmation is triggered when a hardware
+	// wallet signer is waiting on the user to confirm a request directly
+	// on their device, rather than in the Status UI.
+	EventSignRequestDeviceConfirmation = "sign-request-device-confirmation"
+)
+
+// signRequestAddedEvent is the payload sent alongside EventSignRequestAdded.
+type signRequestAddedEvent struct {
+	ID     string      `json:"id"`
+	Method string      `json:"method"`
+	Args   interface{} `json:"args"`
+	// Signer identifies which Signer implementation will handle this
+	// request if approved, so the UI can render "confirm on device" for a
+	// hardware wallet instead of an "enter password" prompt.
+	Signer SignerKind `json:"signer"`
+
+	// FieldTree is set for eth_signTypedData_v4 requests, giving the UI a
+	// human-readable view of the payload being signed without it needing
+	// to understand EIP-712 encoding.
+	FieldTree *TypedDataFieldTree `json:"fieldTree,omitempty"`
+}
+
+// Reasons a pending sign request can complete with an error, surfaced on
+// EventSignRequestFailed so the UI can tell a user rejection apart from a
+// programmatic cancellation or a timeout.
+const (
+	ReasonDiscarded = "discarded"
+	ReasonCancelled = "cancelled"
+	ReasonTimedOut  = "timed-out"
+	ReasonFailed    = "failed"
+)
+
+// signRequestFailedEvent is the payload sent alongside EventSignRequestFailed.
+type signRequestFailedEvent struct {
+	ID     string `json:"id"`
+	Error  string `json:"error_message"`
+	Reason string `json:"reason"`
+}
+
+// reasonFor classifies err into one of the Reason* constants.
+func reasonFor(err error) string {
+	switch err {
+	case ErrSignReqDiscarded:
+		return ReasonDiscarded
+	case ErrSignReqCancelled:
+		return ReasonCancelled
+	case ErrSignReqTimedOut:
+		return ReasonTimedOut
+	default:
+		return ReasonFailed
+	}
+}
+
+// NotifyOnEnqueue sends a signal notifying subscribers that a new sign
+// request has been queued and is awaiting approval.
+func NotifyOnEnqueue(request *Request) {
+	if request == nil {
+		return
+	}
+
+	event := signRequestAddedEvent{
+		ID:     request.ID,
+		Method: request.Method,
+		Args:   request.Meta,
+		Signer: request.SignerKind,
+	}
+	if request.Meta.TypedData != nil {
+		tree := newTypedDataFieldTree(*request.Meta.TypedData)
+		event.FieldTree = &tree
+	}
+
+	statussignal.SendSignal(statussignal.Envelope{
+		Type:  EventSignRequestAdded,
+		Event: event,
+	})
+}
+
+// signRequestDeviceConfirmationEvent is the payload sent alongside
+// EventSignRequestDeviceConfirmation.
+type signRequestDeviceConfirmationEvent struct {
+	ID string `json:"id"`
+}
+
+// NotifyOnDeviceConfirmation sends a signal notifying subscribers that
+// request is now waiting on the user to confirm it on a hardware wallet.
+func NotifyOnDeviceConfirmation(request *Request) {
+	if request == nil {
+		return
+	}
+	statussignal.SendSignal(statussignal.Envelope{
+		Type:  EventSignRequestDeviceConfirmation,
+		Event: signRequestDeviceConfirmationEvent{ID: request.ID},
+	})
+}
+
+// NotifyOnReturn sends a signal notifying subscribers that a pending sign
+// request has completed with an error (a nil err means it was signed
+// successfully and callers relying on the returned response don't need a signal).
+func NotifyOnReturn(request *Request, err error) {
+	if request == nil || err == nil {
+		return
+	}
+	statussignal.SendSignal(statussignal.Envelope{
+		Type: EventSignRequestFailed,
+		Event: signRequestFailedEvent{
+			ID:     request.ID,
+			Error:  err.Error(),
+			Reason: reasonFor(err),
+		},
+	})
+}
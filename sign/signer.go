@@ -0,0 +1,220 @@
+package sign
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// SignerKind identifies which Signer implementation handles a request, so
+// the notification envelope can tell the UI which approval flow to render
+// ("enter password" vs "confirm on device" vs a silent remote approval).
+type SignerKind string
+
+const (
+	// SignerKeystore signs with a local, password-encrypted keystore key.
+	// This is the default, and the only kind available before per-request
+	// signer selection was introduced.
+	SignerKeystore SignerKind = "keystore"
+	// SignerHardwareWallet signs via an attached Ledger/Trezor device.
+	SignerHardwareWallet SignerKind = "hardware-wallet"
+	// SignerRemoteKMS signs by delegating to an external JSON-RPC/HTTP KMS.
+	SignerRemoteKMS SignerKind = "remote-kms"
+)
+
+// ErrNoSignerForAccount is returned by a SignerSelector when it doesn't
+// recognize a request's account; PendingRequests then falls back to the
+// default keystore signer.
+var ErrNoSignerForAccount = errors.New("no signer is configured for this account")
+
+// Signer performs the actual signing/transaction operation for a Request,
+// once it's been approved.
+type Signer interface {
+	// Kind identifies the Signer implementation.
+	Kind() SignerKind
+	// Sign executes request, returning its Result.
+	Sign(ctx context.Context, request *Request) Result
+}
+
+// SignerSelector picks the Signer that should handle request, based on its
+// account. It should return ErrNoSignerForAccount (and a nil Signer) for
+// accounts it doesn't recognize, so PendingRequests can fall back to the
+// default keystore signer.
+type SignerSelector func(request *Request) (Signer, error)
+
+// KeystoreSigner signs using a local keystore account unlocked with a
+// passphrase. It wraps PendingRequests' original, pre-Signer approval path.
+type KeystoreSigner struct {
+	password string
+	verify   verifyFunc
+}
+
+// NewKeystoreSigner creates a KeystoreSigner that unlocks the account with
+// password via verify.
+func NewKeystoreSigner(password string, verify verifyFunc) *KeystoreSigner {
+	return &KeystoreSigner{password: password, verify: verify}
+}
+
+// Kind implements Signer.
+func (s *KeystoreSigner) Kind() SignerKind { return SignerKeystore }
+
+// Sign implements Signer.
+func (s *KeystoreSigner) Sign(ctx context.Context, request *Request) Result {
+	selectedAccount, err := s.verify(s.password)
+	if err != nil {
+		return Result{Error: err}
+	}
+
+	response, err := request.completeFunc(selectedAccount)
+	return Result{Response: response, Error: err}
+}
+
+// HardwareWalletSigner signs using an attached Ledger/Trezor device, found
+// through go-ethereum's accounts/usbwallet Hub. Unlike KeystoreSigner it
+// doesn't need a password: the device itself prompts the user to confirm,
+// so Sign notifies subscribers of that instead.
+type HardwareWalletSigner struct {
+	// findWallet resolves the accounts.Wallet and accounts.Account backing
+	// address, typically account.Manager.Find wired through a USB hub.
+	findWallet func(address gethcommon.Address) (accounts.Wallet, accounts.Account, error)
+}
+
+// NewHardwareWalletSigner creates a HardwareWalletSigner that resolves
+// wallets through findWallet.
+func NewHardwareWalletSigner(findWallet func(gethcommon.Address) (accounts.Wallet, accounts.Account, error)) *HardwareWalletSigner {
+	return &HardwareWalletSigner{findWallet: findWallet}
+}
+
+// Kind implements Signer.
+func (s *HardwareWalletSigner) Kind() SignerKind { return SignerHardwareWallet }
+
+// Sign implements Signer.
+func (s *HardwareWalletSigner) Sign(ctx context.Context, request *Request) Result {
+	wallet, walletAccount, err := s.findWallet(gethcommon.HexToAddress(request.Meta.Account))
+	if err != nil {
+		return Result{Error: err}
+	}
+
+	NotifyOnDeviceConfirmation(request)
+
+	// Sign the exact same digest a completeFunc built by this package would
+	// (see signingDigest), not a re-derivation of Meta: request.Meta.Data is
+	// a display-oriented hex string, and re-encoding it naively here would
+	// silently produce a different signature than the keystore path for the
+	// same Request.
+	hash, err := signingDigest(request.Meta)
+	if err != nil {
+		return Result{Error: err}
+	}
+
+	// SignData blocks until the user confirms on the device, so it runs in
+	// its own goroutine and Sign gives up once ctx is done.
+	signed := make(chan Result, 1)
+	go func() {
+		response, err := wallet.SignData(walletAccount, accounts.MimetypeTextPlain, hash.Bytes())
+		signed <- Result{Response: response, Error: err}
+	}()
+	select {
+	case result := <-signed:
+		return result
+	case <-ctx.Done():
+		return Result{Error: ctx.Err()}
+	}
+}
+
+// RemoteKMSSigner delegates signing to an external JSON-RPC/HTTP KMS
+// endpoint, which the caller that wires up a SignerSelector passes to
+// NewRemoteKMSSigner; this package reads no node configuration for it.
+type RemoteKMSSigner struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewRemoteKMSSigner creates a RemoteKMSSigner that posts sign requests to
+// endpoint using client (http.DefaultClient if nil).
+func NewRemoteKMSSigner(endpoint string, client *http.Client) *RemoteKMSSigner {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &RemoteKMSSigner{endpoint: endpoint, client: client}
+}
+
+// Kind implements Signer.
+func (s *RemoteKMSSigner) Kind() SignerKind { return SignerRemoteKMS }
+
+type kmsSignRequest struct {
+	Account string `json:"account"`
+	Method  string `json:"method"`
+	// Data and TypedData are included only so the endpoint can display the
+	// original request to an operator/audit log; the KMS must sign Digest,
+	// not re-derive it from these.
+	Data      string     `json:"data,omitempty"`
+	TypedData *TypedData `json:"typedData,omitempty"`
+	// Digest is the canonical, hex-encoded 32-byte digest the KMS must sign
+	// (see signingDigest) - the same bytes the keystore and hardware-wallet
+	// signers sign for this Request.
+	Digest string `json:"digest"`
+}
+
+type kmsSignResponse struct {
+	Signature string `json:"signature"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Sign implements Signer.
+func (s *RemoteKMSSigner) Sign(ctx context.Context, request *Request) Result {
+	hash, err := signingDigest(request.Meta)
+	if err != nil {
+		return Result{Error: err}
+	}
+
+	payload, err := json.Marshal(kmsSignRequest{
+		Account:   request.Meta.Account,
+		Method:    request.Method,
+		Data:      request.Meta.Data,
+		TypedData: request.Meta.TypedData,
+		Digest:    hash.Hex(),
+	})
+	if err != nil {
+		return Result{Error: err}
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return Result{Error: err}
+	}
+	httpReq = httpReq.WithContext(ctx)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return Result{Error: err}
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return Result{Error: fmt.Errorf("remote KMS returned status %d", resp.StatusCode)}
+	}
+
+	var kmsResp kmsSignResponse
+	if err := json.NewDecoder(resp.Body).Decode(&kmsResp); err != nil {
+		return Result{Error: err}
+	}
+	if kmsResp.Error != "" {
+		return Result{Error: errors.New(kmsResp.Error)}
+	}
+
+	response, err := hexutil.Decode(kmsResp.Signature)
+	if err != nil {
+		return Result{Error: err}
+	}
+
+	return Result{Response: response}
+}
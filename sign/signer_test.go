@@ -0,0 +1,136 @@
+package sign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWallet is a minimal accounts.Wallet that signs whatever bytes it's
+// asked to with a fixed key, so tests can check exactly what
+// HardwareWalletSigner hands it.
+type fakeWallet struct {
+	account accounts.Account
+	key     *ecdsa.PrivateKey
+}
+
+func (w *fakeWallet) URL() accounts.URL                  { return accounts.URL{} }
+func (w *fakeWallet) Status() (string, error)            { return "", nil }
+func (w *fakeWallet) Open(passphrase string) error       { return nil }
+func (w *fakeWallet) Close() error                       { return nil }
+func (w *fakeWallet) Accounts() []accounts.Account       { return []accounts.Account{w.account} }
+func (w *fakeWallet) Contains(acc accounts.Account) bool { return acc.Address == w.account.Address }
+func (w *fakeWallet) Derive(accounts.DerivationPath, bool) (accounts.Account, error) {
+	return accounts.Account{}, errors.New("not implemented")
+}
+func (w *fakeWallet) SelfDerive([]accounts.DerivationPath, ethereum.ChainStateReader) {}
+
+func (w *fakeWallet) SignData(_ accounts.Account, _ string, data []byte) ([]byte, error) {
+	sig, err := crypto.Sign(data, w.key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+func (w *fakeWallet) SignDataWithPassphrase(acc accounts.Account, _, mimeType string, data []byte) ([]byte, error) {
+	return w.SignData(acc, mimeType, data)
+}
+
+func (w *fakeWallet) SignText(acc accounts.Account, text []byte) ([]byte, error) {
+	return w.SignData(acc, accounts.MimetypeTextPlain, text)
+}
+
+func (w *fakeWallet) SignTextWithPassphrase(acc accounts.Account, _ string, hash []byte) ([]byte, error) {
+	return w.SignText(acc, hash)
+}
+
+func (w *fakeWallet) SignTx(accounts.Account, *types.Transaction, *big.Int) (*types.Transaction, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (w *fakeWallet) SignTxWithPassphrase(accounts.Account, string, *types.Transaction, *big.Int) (*types.Transaction, error) {
+	return nil, errors.New("not implemented")
+}
+
+// TestHardwareWalletSigner_MatchesKeystoreSignerForPersonalSign is a
+// regression test: HardwareWalletSigner used to sign the raw ASCII bytes of
+// Meta.Data (a display-oriented hex string) instead of the personal_sign
+// digest PersonalSignCompleteFunc signs, so the same Request produced
+// different signatures depending on which Signer handled it.
+func TestHardwareWalletSigner_MatchesKeystoreSignerForPersonalSign(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	meta := Meta{Method: "personal_sign", Account: address.Hex(), Data: "0xbaadbeef"}
+	request := newRequest(context.Background(), meta, PersonalSignCompleteFunc(meta.Data), 0)
+
+	keystoreSigner := NewKeystoreSigner("password", func(string) (*account.SelectedExtKey, error) {
+		return &account.SelectedExtKey{AccountKey: &keystore.Key{PrivateKey: key}}, nil
+	})
+	keystoreResult := keystoreSigner.Sign(context.Background(), request)
+	require.NoError(t, keystoreResult.Error)
+
+	wallet := &fakeWallet{account: accounts.Account{Address: address}, key: key}
+	hwSigner := NewHardwareWalletSigner(func(gethcommon.Address) (accounts.Wallet, accounts.Account, error) {
+		return wallet, wallet.account, nil
+	})
+	hwResult := hwSigner.Sign(context.Background(), request)
+	require.NoError(t, hwResult.Error)
+
+	require.Equal(t, keystoreResult.Response, hwResult.Response)
+}
+
+// TestHardwareWalletSigner_MatchesKeystoreSignerForTypedData mirrors the
+// personal_sign regression test above, for eth_signTypedData_v4 requests.
+func TestHardwareWalletSigner_MatchesKeystoreSignerForTypedData(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	data := TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+			},
+			"Mail": {
+				{Name: "contents", Type: "string"},
+			},
+		},
+		PrimaryType: "Mail",
+		Domain:      apitypes.TypedDataDomain{Name: "status-go test"},
+		Message:     apitypes.TypedDataMessage{"contents": "hello"},
+	}
+
+	meta := NewTypedDataMeta(address.Hex(), data)
+	request := newRequest(context.Background(), meta, TypedDataCompleteFunc(data), 0)
+
+	keystoreSigner := NewKeystoreSigner("password", func(string) (*account.SelectedExtKey, error) {
+		return &account.SelectedExtKey{AccountKey: &keystore.Key{PrivateKey: key}}, nil
+	})
+	keystoreResult := keystoreSigner.Sign(context.Background(), request)
+	require.NoError(t, keystoreResult.Error)
+
+	wallet := &fakeWallet{account: accounts.Account{Address: address}, key: key}
+	hwSigner := NewHardwareWalletSigner(func(gethcommon.Address) (accounts.Wallet, accounts.Account, error) {
+		return wallet, wallet.account, nil
+	})
+	hwResult := hwSigner.Sign(context.Background(), request)
+	require.NoError(t, hwResult.Error)
+
+	require.Equal(t, keystoreResult.Response, hwResult.Response)
+}
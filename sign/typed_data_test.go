@@ -0,0 +1,63 @@
+package sign
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/status-im/status-go/geth/account"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedDataCompleteFunc_RecoversSignerAddress(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	data := TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+			},
+			"Mail": {
+				{Name: "from", Type: "address"},
+				{Name: "contents", Type: "string"},
+			},
+		},
+		PrimaryType: "Mail",
+		Domain: apitypes.TypedDataDomain{
+			Name:    "status-go test",
+			Version: "1",
+			ChainId: (*math.HexOrDecimal256)(big.NewInt(1)),
+		},
+		Message: apitypes.TypedDataMessage{
+			"from":     address.Hex(),
+			"contents": "hello",
+		},
+	}
+
+	selectedAccount := &account.SelectedExtKey{AccountKey: &keystore.Key{PrivateKey: key}}
+
+	response, err := TypedDataCompleteFunc(data)(selectedAccount)
+	require.NoError(t, err)
+	require.Len(t, response, 65)
+
+	hash, err := hashTypedData(data)
+	require.NoError(t, err)
+
+	// crypto.Sign returns v in the {0,1} range, but TypedDataCompleteFunc
+	// shifts it to the {27,28} range used by personal_sign/EIP-712
+	// signatures; undo that before recovering the public key.
+	sig := make([]byte, len(response))
+	copy(sig, response)
+	sig[64] -= 27
+
+	pub, err := crypto.SigToPub(hash.Bytes(), sig)
+	require.NoError(t, err)
+	require.Equal(t, address, crypto.PubkeyToAddress(*pub))
+}